@@ -0,0 +1,160 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// listenerEnv holds the number of inherited listener file descriptors,
+// starting at fd 3 (à la systemd's LISTEN_FDS socket activation, and the
+// overmind/air graceful-restart pattern).
+const listenerEnv = "RELOAD_LISTENERS"
+
+// defaultGracefulTimeout is used for [WithGraceful] if no timeout is given.
+const defaultGracefulTimeout = 5 * time.Second
+
+var (
+	graceful        bool
+	gracefulTimeout time.Duration
+
+	listenersMu sync.Mutex
+	listeners   []net.Listener
+
+	preExecHooksMu sync.Mutex
+	preExecHooks   []func(context.Context)
+)
+
+// WithGraceful switches [Exec] from [syscall.Exec] to a graceful restart: the
+// listeners registered with [Listener] are handed over to a newly started
+// child process (which resumes them with [InheritedListeners]) instead of
+// being closed, so in-flight requests on them aren't dropped. Before the
+// child starts, the hooks registered with [OnPreExec] are run with a context
+// that's cancelled after timeout; if timeout is 0, [defaultGracefulTimeout]
+// (5s) is used.
+//
+// Unlike a plain [Exec] restart, the old process doesn't get replaced in
+// place: a new process is started and the old one calls os.Exit once the
+// handover is done.
+func WithGraceful(timeout time.Duration) Option {
+	return func(cfg *config) {
+		cfg.graceful = true
+		cfg.gracefulTimeout = timeout
+	}
+}
+
+// Listener registers l to be handed over to the child process on a graceful
+// restart (see [WithGraceful]); l must support handover, which every
+// *[net.TCPListener] and *[net.UnixListener] does. It returns l unchanged so
+// it can wrap the call that creates it:
+//
+//	ln = reload.Listener(ln)
+func Listener(l net.Listener) net.Listener {
+	listenersMu.Lock()
+	listeners = append(listeners, l)
+	listenersMu.Unlock()
+	return l
+}
+
+// OnPreExec registers fn to run before a graceful restart hands listeners
+// over to the child process; fn should stop accepting new work and return
+// once it's safe to hand the listeners over, respecting ctx's deadline. Can
+// be called more than once; every hook runs concurrently.
+func OnPreExec(fn func(ctx context.Context)) {
+	preExecHooksMu.Lock()
+	preExecHooks = append(preExecHooks, fn)
+	preExecHooksMu.Unlock()
+}
+
+// InheritedListeners returns the listeners passed down by the parent process
+// on a graceful restart (see [WithGraceful]); it returns an empty slice if
+// this process wasn't started as part of one.
+func InheritedListeners() ([]net.Listener, error) {
+	n, _ := strconv.Atoi(os.Getenv(listenerEnv))
+	if n == 0 {
+		return nil, nil
+	}
+
+	ls := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		f := os.NewFile(uintptr(3+i), fmt.Sprintf("reload-listener-%d", i))
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reload.InheritedListeners: fd %d: %w", 3+i, err)
+		}
+		ls = append(ls, l)
+	}
+	return ls, nil
+}
+
+// filer is implemented by listener types that can hand their underlying file
+// descriptor over to a child process, such as *net.TCPListener.
+type filer interface {
+	File() (*os.File, error)
+}
+
+// execGraceful runs the pre-exec hooks, then starts execName as a child
+// process with every registered [Listener] passed via ExtraFiles, and exits
+// the current process. Unlike the syscall.Exec path in [Exec] it does not
+// replace the current process image.
+func execGraceful(execName string) {
+	timeout := gracefulTimeout
+	if timeout <= 0 {
+		timeout = defaultGracefulTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	preExecHooksMu.Lock()
+	hooks := append([]func(context.Context){}, preExecHooks...)
+	preExecHooksMu.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(len(hooks))
+	for _, h := range hooks {
+		go func(h func(context.Context)) {
+			defer wg.Done()
+			h(ctx)
+		}(h)
+	}
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	listenersMu.Lock()
+	ls := append([]net.Listener{}, listeners...)
+	listenersMu.Unlock()
+
+	files := make([]*os.File, 0, len(ls))
+	for _, l := range ls {
+		f, ok := l.(filer)
+		if !ok {
+			panic(fmt.Sprintf("reload: listener %v does not support handover (no File method)", l))
+		}
+		file, err := f.File()
+		if err != nil {
+			panic(fmt.Sprintf("cannot restart: cannot get file for listener %v: %v", l, err))
+		}
+		files = append(files, file)
+	}
+
+	env := append(os.Environ(), fmt.Sprintf("%s=%d", listenerEnv, len(files)))
+	_, err := os.StartProcess(execName, append([]string{execName}, os.Args[1:]...), &os.ProcAttr{
+		Files: append([]*os.File{os.Stdin, os.Stdout, os.Stderr}, files...),
+		Env:   env,
+	})
+	if err != nil {
+		reloadFailuresTotal.Add(1)
+		notify(Notification{Kind: EventExecFailed, Time: time.Now(), Path: execName})
+		panic(fmt.Sprintf("cannot restart: %v", err))
+	}
+	os.Exit(0)
+}
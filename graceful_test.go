@@ -0,0 +1,74 @@
+package reload
+
+import (
+	"net"
+	"os"
+	"testing"
+)
+
+func setListenerEnv(t *testing.T, v string) {
+	t.Helper()
+	old, had := os.LookupEnv(listenerEnv)
+	if v == "" {
+		os.Unsetenv(listenerEnv)
+	} else {
+		os.Setenv(listenerEnv, v)
+	}
+	t.Cleanup(func() {
+		if had {
+			os.Setenv(listenerEnv, old)
+		} else {
+			os.Unsetenv(listenerEnv)
+		}
+	})
+}
+
+func TestInheritedListenersNoEnv(t *testing.T) {
+	setListenerEnv(t, "")
+
+	ls, err := InheritedListeners()
+	if err != nil || ls != nil {
+		t.Fatalf("InheritedListeners() = %v, %v; want nil, nil", ls, err)
+	}
+}
+
+func TestInheritedListenersMalformedEnv(t *testing.T) {
+	// strconv.Atoi failing (e.g. the env var got clobbered by something
+	// else) should be treated the same as "not set", not panic or try to
+	// open fd 3 anyway.
+	setListenerEnv(t, "not-a-number")
+
+	ls, err := InheritedListeners()
+	if err != nil || ls != nil {
+		t.Fatalf("InheritedListeners() = %v, %v; want nil, nil", ls, err)
+	}
+}
+
+func TestListenerRegistersForHandover(t *testing.T) {
+	listenersMu.Lock()
+	old := listeners
+	listeners = nil
+	listenersMu.Unlock()
+	t.Cleanup(func() {
+		listenersMu.Lock()
+		listeners = old
+		listenersMu.Unlock()
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	if got := Listener(ln); got != ln {
+		t.Fatalf("Listener() returned %v, want the same listener back", got)
+	}
+
+	listenersMu.Lock()
+	n := len(listeners)
+	listenersMu.Unlock()
+	if n != 1 {
+		t.Fatalf("listeners has %d entries, want 1", n)
+	}
+}
@@ -0,0 +1,212 @@
+package reload
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watcher is the subset of *fsnotify.Watcher's behaviour Do needs; it's
+// implemented by [fsnotifyWatcher] and [pollWatcher] so Do can fall back to
+// polling without caring which one it's talking to.
+type watcher interface {
+	Events() <-chan fsnotify.Event
+	Errors() <-chan error
+	Add(path string) error
+	Remove(path string) error
+	Close() error
+}
+
+// fsnotifyWatcher adapts *fsnotify.Watcher (whose Events and Errors are
+// fields, not methods) to the watcher interface.
+type fsnotifyWatcher struct{ w *fsnotify.Watcher }
+
+func (f fsnotifyWatcher) Events() <-chan fsnotify.Event { return f.w.Events }
+func (f fsnotifyWatcher) Errors() <-chan error          { return f.w.Errors }
+func (f fsnotifyWatcher) Add(path string) error         { return f.w.Add(path) }
+func (f fsnotifyWatcher) Remove(path string) error      { return f.w.Remove(path) }
+func (f fsnotifyWatcher) Close() error                  { return f.w.Close() }
+
+// entryState is the bit of os.FileInfo we diff to detect a change: inotify
+// tells us a file was written, the poller has to infer it from stat(2).
+type entryState struct {
+	mtime time.Time
+	size  int64
+	ino   uint64
+}
+
+// pollWatcher is a fallback for watcher that works on filesystems where
+// fsnotify either errors out or silently drops events (network mounts, some
+// container volumes, and a few exotic filesystems). It polls the immediate
+// contents of every watched directory on a fixed interval and synthesizes
+// fsnotify.Create/Write/Remove events by comparing mtime, size and inode
+// against the previous poll.
+type pollWatcher struct {
+	interval time.Duration
+	events   chan fsnotify.Event
+	errors   chan error
+	done     chan struct{}
+
+	mu    sync.Mutex
+	dirs  []string
+	state map[string]entryState // full path → last seen state
+}
+
+func newPollWatcher(interval time.Duration) *pollWatcher {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	p := &pollWatcher{
+		interval: interval,
+		events:   make(chan fsnotify.Event),
+		errors:   make(chan error),
+		done:     make(chan struct{}),
+		state:    make(map[string]entryState),
+	}
+	go p.run()
+	return p
+}
+
+func (p *pollWatcher) Events() <-chan fsnotify.Event { return p.events }
+func (p *pollWatcher) Errors() <-chan error          { return p.errors }
+
+func (p *pollWatcher) Add(path string) error {
+	entries, err := p.scan(path)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dirs = append(p.dirs, path)
+	for name, s := range entries {
+		p.state[name] = s
+	}
+	return nil
+}
+
+func (p *pollWatcher) Remove(path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, d := range p.dirs {
+		if d == path {
+			p.dirs = append(p.dirs[:i], p.dirs[i+1:]...)
+			break
+		}
+	}
+	prefix := path + string(os.PathSeparator)
+	for name := range p.state {
+		if name == path || strings.HasPrefix(name, prefix) {
+			delete(p.state, name)
+		}
+	}
+	return nil
+}
+
+func (p *pollWatcher) Close() error {
+	close(p.done)
+	return nil
+}
+
+func (p *pollWatcher) run() {
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-t.C:
+			p.poll()
+		}
+	}
+}
+
+func (p *pollWatcher) poll() {
+	p.mu.Lock()
+	dirs := append([]string(nil), p.dirs...)
+	p.mu.Unlock()
+
+	for _, dir := range dirs {
+		entries, err := p.scan(dir)
+		if err != nil {
+			select {
+			case p.errors <- err:
+			case <-p.done:
+				return
+			}
+			continue
+		}
+
+		p.mu.Lock()
+		for name, s := range entries {
+			old, ok := p.state[name]
+			p.state[name] = s
+			if !ok {
+				p.mu.Unlock()
+				p.send(fsnotify.Event{Name: name, Op: fsnotify.Create})
+				p.mu.Lock()
+				continue
+			}
+			if old != s {
+				p.mu.Unlock()
+				p.send(fsnotify.Event{Name: name, Op: fsnotify.Write})
+				p.mu.Lock()
+			}
+		}
+		prefix := dir + string(os.PathSeparator)
+		for name := range p.state {
+			if !strings.HasPrefix(name, prefix) || strings.ContainsRune(name[len(prefix):], os.PathSeparator) {
+				continue // Belongs to another watched directory.
+			}
+			if _, ok := entries[name]; !ok {
+				delete(p.state, name)
+				p.mu.Unlock()
+				p.send(fsnotify.Event{Name: name, Op: fsnotify.Remove})
+				p.mu.Lock()
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+func (p *pollWatcher) send(event fsnotify.Event) {
+	select {
+	case p.events <- event:
+	case <-p.done:
+	}
+}
+
+// scan stats every entry directly inside dir and returns its state, keyed by
+// full path.
+func (p *pollWatcher) scan(dir string) (map[string]entryState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]entryState, len(entries))
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue // Entry was removed between ReadDir and Info; it'll show up as a Remove next poll.
+		}
+		out[dir+string(os.PathSeparator)+e.Name()] = entryState{
+			mtime: info.ModTime(),
+			size:  info.Size(),
+			ino:   inode(info),
+		}
+	}
+	return out, nil
+}
+
+func inode(info os.FileInfo) uint64 {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0
+	}
+	return st.Ino
+}
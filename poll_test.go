@@ -0,0 +1,100 @@
+package reload
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestPollWatcherCreateWriteRemove(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(file, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := newPollWatcher(10 * time.Millisecond)
+	defer p.Close()
+
+	if err := p.Add(dir); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	// The file existed before Add, so it shouldn't be reported as a Create.
+	select {
+	case ev := <-p.Events():
+		t.Fatalf("unexpected event for pre-existing file: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	newFile := filepath.Join(dir, "b.txt")
+	if err := os.WriteFile(newFile, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, p, newFile, fsnotify.Create)
+
+	if err := os.WriteFile(file, []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, p, file, fsnotify.Write)
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, p, file, fsnotify.Remove)
+}
+
+func TestPollWatcherRemoveStopsWatching(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(file, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := newPollWatcher(10 * time.Millisecond)
+	defer p.Close()
+
+	if err := p.Add(dir); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := p.Remove(dir); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	p.mu.Lock()
+	n := len(p.state)
+	dirs := len(p.dirs)
+	p.mu.Unlock()
+	if n != 0 || dirs != 0 {
+		t.Fatalf("state after Remove: %d entries, %d dirs; want 0, 0", n, dirs)
+	}
+
+	if err := os.WriteFile(file, []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-p.Events():
+		t.Fatalf("got event after Remove: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func waitEvent(t *testing.T, p *pollWatcher, wantName string, wantOp fsnotify.Op) {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case ev := <-p.Events():
+			if ev.Name == wantName && ev.Op == wantOp {
+				return
+			}
+		case err := <-p.Errors():
+			t.Fatalf("unexpected poll error: %v", err)
+		case <-deadline:
+			t.Fatalf("timed out waiting for %s on %q", wantOp, wantName)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Package prometheus exposes github.com/arp242/reload's [reload.Stats] as a
+// prometheus.Collector.
+//
+// This is a separate package so that importing the main reload package
+// doesn't pull in github.com/prometheus/client_golang and its dependencies
+// for callers who don't need metrics.
+package prometheus
+
+import (
+	"github.com/arp242/reload"
+	promclient "github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	reloadsTotalDesc = promclient.NewDesc(
+		"reload_reloads_total", "Total number of times the process restarted itself.", nil, nil)
+	reloadFailuresTotalDesc = promclient.NewDesc(
+		"reload_reload_failures_total", "Total number of restarts that failed to exec.", nil, nil)
+	eventsDroppedTotalDesc = promclient.NewDesc(
+		"reload_events_dropped_total", "Total number of notifications dropped because a Subscribe channel was full.", nil, nil)
+	lastReloadAtDesc = promclient.NewDesc(
+		"reload_last_reload_at_seconds", "Unix timestamp of the last restart, or 0 if none happened yet.", nil, nil)
+)
+
+// collector implements promclient.Collector for [reload.Stats].
+type collector struct{}
+
+// NewCollector returns a promclient.Collector exposing reload's counters, for
+// use with prometheus.MustRegister.
+func NewCollector() promclient.Collector { return collector{} }
+
+func (collector) Describe(ch chan<- *promclient.Desc) {
+	ch <- reloadsTotalDesc
+	ch <- reloadFailuresTotalDesc
+	ch <- eventsDroppedTotalDesc
+	ch <- lastReloadAtDesc
+}
+
+func (collector) Collect(ch chan<- promclient.Metric) {
+	s := reload.GetStats()
+	ch <- promclient.MustNewConstMetric(reloadsTotalDesc, promclient.CounterValue, float64(s.ReloadsTotal))
+	ch <- promclient.MustNewConstMetric(reloadFailuresTotalDesc, promclient.CounterValue, float64(s.ReloadFailuresTotal))
+	ch <- promclient.MustNewConstMetric(eventsDroppedTotalDesc, promclient.CounterValue, float64(s.EventsDroppedTotal))
+	var ts float64
+	if !s.LastReloadAt.IsZero() {
+		ts = float64(s.LastReloadAt.Unix())
+	}
+	ch <- promclient.MustNewConstMetric(lastReloadAtDesc, promclient.GaugeValue, ts)
+}
@@ -0,0 +1,35 @@
+package prometheus
+
+import (
+	"testing"
+
+	promclient "github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollectorDescribe(t *testing.T) {
+	ch := make(chan *promclient.Desc, 10)
+	NewCollector().Describe(ch)
+	close(ch)
+
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != 4 {
+		t.Errorf("Describe sent %d descs, want 4", n)
+	}
+}
+
+func TestCollectorCollect(t *testing.T) {
+	ch := make(chan promclient.Metric, 10)
+	NewCollector().Collect(ch)
+	close(ch)
+
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != 4 {
+		t.Errorf("Collect sent %d metrics, want 4", n)
+	}
+}
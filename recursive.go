@@ -0,0 +1,236 @@
+package reload
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DirOption configures a directory tree added with [DirRecursive].
+type DirOption func(*dirConfig)
+
+type dirConfig struct {
+	includes []string
+	excludes []string
+}
+
+// Include only files matching pattern from triggering the callback passed to
+// [DirRecursive]. pattern is matched with [path/filepath.Match] against both
+// the file's base name and its path relative to the watched root; note that,
+// unlike some glob dialects, [path/filepath.Match] has no recursive "**"
+// syntax, so a relative-path pattern like "tpl/*.tmpl" only matches one
+// directory deep. To match a file by extension at any depth, match its base
+// name instead (e.g. "*.tmpl" matches foo.tmpl at any depth, since the base
+// name comparison ignores its directory). Can be given more than once; a
+// file only needs to match one Include pattern. Without any Include option
+// every file is eligible.
+func Include(pattern string) DirOption {
+	return func(dc *dirConfig) { dc.includes = append(dc.includes, pattern) }
+}
+
+// Exclude files and directories matching any of patterns from [DirRecursive];
+// patterns are matched the same way as in [Include]. Excluded directories
+// aren't descended into.
+func Exclude(patterns ...string) DirOption {
+	return func(dc *dirConfig) { dc.excludes = append(dc.excludes, patterns...) }
+}
+
+// recursiveDir is a directory tree registered with [DirRecursive].
+type recursiveDir struct {
+	root     string
+	cb       func(string)
+	includes []string
+	excludes []string
+}
+
+// DirRecursive is an additional directory tree to watch for changes.
+//
+// Unlike [Dir] this descends into subdirectories, and keeps watching
+// subdirectories created after startup (and stops watching ones that are
+// removed or renamed away). Use [Include] and [Exclude] to only trigger cb
+// for files that match; with neither option every file under path does.
+//
+// cb is called with the full path of the file that changed, so callers can
+// do a partial reload rather than calling [Exec].
+func DirRecursive(path string, cb func(path string), opts ...DirOption) Option {
+	var dc dirConfig
+	for _, o := range opts {
+		o(&dc)
+	}
+	return func(cfg *config) {
+		cfg.recursive = append(cfg.recursive, recursiveDir{
+			root: path, cb: cb, includes: dc.includes, excludes: dc.excludes,
+		})
+	}
+}
+
+// match reports whether name or rel matches any of patterns; with no
+// patterns it reports def.
+func match(patterns []string, name, rel string, def bool) bool {
+	if len(patterns) == 0 {
+		return def
+	}
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(p, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (rd recursiveDir) relTo(path string) (name, rel string) {
+	rel, err := filepath.Rel(rd.root, path)
+	if err != nil {
+		rel = path
+	}
+	return filepath.Base(path), rel
+}
+
+func (rd recursiveDir) excluded(path string) bool {
+	name, rel := rd.relTo(path)
+	return match(rd.excludes, name, rel, false)
+}
+
+func (rd recursiveDir) included(path string) bool {
+	if rd.excluded(path) {
+		return false
+	}
+	name, rel := rd.relTo(path)
+	return match(rd.includes, name, rel, true)
+}
+
+// setupRecursive resolves every DirRecursive root to an absolute path, walks
+// it, and adds every non-excluded subdirectory to w. watched maps every
+// directory currently being watched to the recursiveDir it belongs to, so
+// later events can be routed back to the right callback and filters.
+func setupRecursive(w watcher, cfg *config, watched map[string]*recursiveDir) error {
+	for i := range cfg.recursive {
+		root, err := filepath.Abs(cfg.recursive[i].root)
+		if err != nil {
+			return fmt.Errorf("DirRecursive: cannot get absolute path to %q: %w",
+				cfg.recursive[i].root, err)
+		}
+		cfg.recursive[i].root = root
+		rd := &cfg.recursive[i]
+
+		if err := addTree(w, rd, root, watched); err != nil {
+			return fmt.Errorf("DirRecursive: cannot watch %q: %w", root, err)
+		}
+	}
+	return nil
+}
+
+// addTree walks root and adds every non-excluded subdirectory in it
+// (including root itself) to w, recording each in watched as belonging to
+// rd. It's used both for the initial [DirRecursive] setup and, in
+// [handleRecursiveEvent], for directories that show up fully-formed (e.g.
+// via mv, rsync, or unzip) rather than one mkdir at a time.
+func addTree(w watcher, rd *recursiveDir, root string, watched map[string]*recursiveDir) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && rd.excluded(path) {
+			return filepath.SkipDir
+		}
+		if err := w.Add(path); err != nil {
+			return err
+		}
+		watched[path] = rd
+		return nil
+	})
+}
+
+// recursiveDirFor finds the recursiveDir that owns path, if any.
+func recursiveDirFor(watched map[string]*recursiveDir, path string) *recursiveDir {
+	if rd, ok := watched[path]; ok {
+		return rd
+	}
+	for dir, rd := range watched {
+		if strings.HasPrefix(path, dir+string(os.PathSeparator)) {
+			return rd
+		}
+	}
+	return nil
+}
+
+// handleRecursiveEvent processes event for the DirRecursive subsystem; it
+// reports whether event belonged to it (in which case the caller should skip
+// its own handling of event).
+func handleRecursiveEvent(
+	w watcher, event fsnotify.Event, watched map[string]*recursiveDir,
+	timers map[string]*time.Timer, delay time.Duration, log func(string, ...any),
+) bool {
+	rd := recursiveDirFor(watched, event.Name)
+	if rd == nil {
+		return false
+	}
+
+	switch {
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		if _, ok := watched[event.Name]; ok {
+			if err := w.Remove(event.Name); err != nil {
+				log("reload: cannot stop watching %q: %v", event.Name, err)
+			}
+			delete(watched, event.Name)
+			// event.Name was itself a watched directory: any per-file timers
+			// for files it contained will never see their own Remove event
+			// (fsnotify only reports the directory going away), so sweep
+			// them here instead of leaking them forever.
+			prefix := event.Name + string(os.PathSeparator)
+			for path, t := range timers {
+				if strings.HasPrefix(path, prefix) {
+					t.Stop()
+					delete(timers, path)
+				}
+			}
+		}
+		if t, ok := timers[event.Name]; ok {
+			t.Stop()
+			delete(timers, event.Name)
+		}
+		return true
+
+	case event.Has(fsnotify.Create):
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if !rd.excluded(event.Name) {
+				// Walk it rather than a single w.Add: tools like mv, rsync,
+				// unzip, and git checkout can create a directory that
+				// already has subdirectories in it, and those need to be
+				// watched too, not just event.Name itself.
+				if err := addTree(w, rd, event.Name, watched); err != nil {
+					log("reload: cannot watch %q: %v", event.Name, err)
+				}
+			}
+			return true
+		}
+		fallthrough
+	case event.Has(fsnotify.Write):
+		if !rd.included(event.Name) {
+			return true
+		}
+		notify(Notification{Kind: EventDebounced, Time: time.Now(), Path: event.Name, Op: event.Op})
+		t, ok := timers[event.Name]
+		if !ok {
+			path := event.Name
+			t = stoppedTimer(func() {
+				notify(Notification{Kind: EventAdditionalDirChanged, Time: time.Now(), Path: path})
+				rd.cb(path)
+			})
+			timers[event.Name] = t
+		}
+		t.Reset(delay)
+		return true
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package reload
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fakeWatcher is a minimal in-memory watcher, so recursive.go's directory
+// bookkeeping can be tested without a real fsnotify.Watcher or poller.
+type fakeWatcher struct {
+	mu      sync.Mutex
+	added   []string
+	removed []string
+}
+
+func (f *fakeWatcher) Events() <-chan fsnotify.Event { return nil }
+func (f *fakeWatcher) Errors() <-chan error          { return nil }
+
+func (f *fakeWatcher) Add(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.added = append(f.added, path)
+	return nil
+}
+
+func (f *fakeWatcher) Remove(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.removed = append(f.removed, path)
+	return nil
+}
+
+func (f *fakeWatcher) Close() error { return nil }
+
+func TestMatchIncludedExcluded(t *testing.T) {
+	rd := recursiveDir{
+		root:     "/tpl",
+		includes: []string{"*.tmpl"},
+		excludes: []string{"*.bak", "node_modules"},
+	}
+
+	tests := []struct {
+		path         string
+		wantExcluded bool
+		wantIncluded bool
+	}{
+		{"/tpl/a.tmpl", false, true},
+		{"/tpl/sub/b.tmpl", false, true}, // base-name match works at any depth
+		{"/tpl/a.txt", false, false},     // doesn't match the Include filter
+		{"/tpl/a.tmpl.bak", true, false}, // excluded takes priority over included
+		{"/tpl/node_modules", true, false},
+	}
+	for _, tt := range tests {
+		if got := rd.excluded(tt.path); got != tt.wantExcluded {
+			t.Errorf("excluded(%q) = %v, want %v", tt.path, got, tt.wantExcluded)
+		}
+		if got := rd.included(tt.path); got != tt.wantIncluded {
+			t.Errorf("included(%q) = %v, want %v", tt.path, got, tt.wantIncluded)
+		}
+	}
+}
+
+func TestMatchRelativePathIsOneLevelOnly(t *testing.T) {
+	// filepath.Match has no recursive "**"; a relative-path pattern only
+	// matches at the exact depth it names.
+	rd := recursiveDir{root: "/tpl", includes: []string{"tpl/*.tmpl"}}
+	if rd.included("/tpl/tpl/a.tmpl") == false {
+		t.Error("expected a one-level-deep match to succeed")
+	}
+	if rd.included("/tpl/x/tpl/a.tmpl") {
+		t.Error("relative-path pattern unexpectedly matched two levels deep")
+	}
+}
+
+func TestAddTreeWatchesExistingSubdirs(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "a", "b"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	fw := &fakeWatcher{}
+	rd := &recursiveDir{root: root}
+	watched := make(map[string]*recursiveDir)
+	if err := addTree(fw, rd, root, watched); err != nil {
+		t.Fatalf("addTree: %v", err)
+	}
+
+	for _, want := range []string{root, filepath.Join(root, "a"), filepath.Join(root, "a", "b")} {
+		if _, ok := watched[want]; !ok {
+			t.Errorf("watched[%q] missing", want)
+		}
+	}
+}
+
+func TestHandleRecursiveEventCreateWalksNewSubtree(t *testing.T) {
+	root := t.TempDir()
+	fw := &fakeWatcher{}
+	rd := &recursiveDir{root: root, cb: func(string) {}}
+	watched := map[string]*recursiveDir{root: rd}
+	timers := make(map[string]*time.Timer)
+
+	// Simulate a tool like mv/rsync/unzip creating a/b in one go, with b
+	// already existing inside a at creation time.
+	newDir := filepath.Join(root, "a")
+	if err := os.MkdirAll(filepath.Join(newDir, "b"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	handled := handleRecursiveEvent(fw, fsnotify.Event{Name: newDir, Op: fsnotify.Create},
+		watched, timers, 10*time.Millisecond, func(string, ...any) {})
+	if !handled {
+		t.Fatal("handleRecursiveEvent reported the Create event as not its own")
+	}
+
+	if _, ok := watched[newDir]; !ok {
+		t.Errorf("watched[%q] missing after Create", newDir)
+	}
+	if _, ok := watched[filepath.Join(newDir, "b")]; !ok {
+		t.Errorf("pre-existing subdirectory of %q was never watched", newDir)
+	}
+}
+
+func TestHandleRecursiveEventRemoveCleansUpTimers(t *testing.T) {
+	root := t.TempDir()
+	fw := &fakeWatcher{}
+	rd := &recursiveDir{root: root, cb: func(string) {}}
+	sub := filepath.Join(root, "sub")
+	watched := map[string]*recursiveDir{root: rd, sub: rd}
+	timers := map[string]*time.Timer{
+		filepath.Join(sub, "a.txt"): stoppedTimer(func() {}),
+	}
+
+	handled := handleRecursiveEvent(fw, fsnotify.Event{Name: sub, Op: fsnotify.Remove},
+		watched, timers, 10*time.Millisecond, func(string, ...any) {})
+	if !handled {
+		t.Fatal("handleRecursiveEvent reported the Remove event as not its own")
+	}
+
+	if _, ok := watched[sub]; ok {
+		t.Errorf("watched[%q] still present after Remove", sub)
+	}
+	if len(timers) != 0 {
+		t.Errorf("timers still has %d entries after removing %q, want 0", len(timers), sub)
+	}
+}
@@ -29,14 +29,72 @@
 //
 // This will run reloadTpl if any file in the "tpl" directory changes. The
 // process won't be restarted.
+//
+// [DirRecursive] does the same, but descends into subdirectories and keeps
+// watching new ones as they're created; [Include] and [Exclude] can filter
+// which files trigger the callback.
+//
+// # Resync
+//
+// fsnotify can drop events on flaky or network filesystems. [WithResync]
+// adds a timer-driven safety net that hashes the binary, any additional
+// directories, and any [DirRecursive] trees on a fixed interval, and only
+// fires its callback when that hash actually changed:
+//
+//	go func() {
+//	    err := reload.Do(log.Printf, reload.WithResync(time.Minute, reload.Exec))
+//	    if err != nil {
+//	        panic(err)
+//	    }
+//	}()
+//
+// # Debounce and quiet period
+//
+// By default a matching fsnotify event restarts a 100ms timer, and the
+// callback only runs once that timer fires; [WithDebounce] makes that delay
+// configurable. [WithQuietPeriod] additionally requires a longer period of
+// silence before firing, which is useful to coalesce the burst of writes a
+// build produces into a single reload. [LastEvent] reports what accumulated
+// during the window.
+//
+// # Graceful restart
+//
+// [syscall.Exec] drops in-flight requests and skips deferred cleanup. For an
+// HTTP(S) server, [WithGraceful] instead starts a new process and hands any
+// listener registered with [Listener] over to it via ExtraFiles, after
+// running the hooks registered with [OnPreExec]; the new process picks the
+// listeners back up with [InheritedListeners]:
+//
+//	ln, _ := net.Listen("tcp", ":8080")
+//	ln = reload.Listener(ln)
+//	reload.OnPreExec(func(ctx context.Context) { srv.Shutdown(ctx) })
+//
+//	go func() {
+//	    err := reload.Do(log.Printf, reload.WithGraceful(5*time.Second))
+//	    if err != nil {
+//	        panic(err)
+//	    }
+//	}()
+//
+// # Observability
+//
+// Besides the free-form messages passed to the log function, [Subscribe]
+// exposes a channel of structured [Notification] values, and [GetStats]
+// exposes running counters such as the total number of reloads and
+// failures. github.com/arp242/reload/prometheus has a prometheus.Collector
+// for the same counters, kept out of this package so it doesn't pull in
+// Prometheus for callers who don't need it.
 package reload
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -61,7 +119,184 @@ type dir struct {
 //
 // The second argument is the callback that to run when the directory changes.
 // Use reload.Exec() to restart the process.
-func Dir(path string, cb func()) dir { return dir{path, cb} }
+func Dir(path string, cb func()) Option {
+	return func(cfg *config) { cfg.additional = append(cfg.additional, dir{path, cb}) }
+}
+
+// config holds the options set by [Option] values passed to [Do].
+type config struct {
+	additional      []dir
+	recursive       []recursiveDir
+	poller          bool
+	pollInterval    time.Duration
+	resync          bool
+	resyncEvery     time.Duration
+	onSync          func()
+	debounce        time.Duration
+	quietPeriod     time.Duration
+	graceful        bool
+	gracefulTimeout time.Duration
+}
+
+// Option is an option that can be passed to [Do] to control its behaviour.
+type Option func(*config)
+
+// defaultPollInterval is used for [WithPoller] if no interval is given, and as
+// the interval for the automatic fallback described there.
+const defaultPollInterval = 1 * time.Second
+
+// WithPoller forces reload to use a polling watcher instead of fsnotify.
+//
+// This is useful on network mounts, some container volumes, and other
+// filesystems where inotify either isn't available or silently drops events.
+// The poller stats every watched directory's entries every interval and
+// compares mtime, size and inode to detect changes; if interval is 0,
+// [defaultPollInterval] (1 second) is used.
+//
+// Do falls back to polling automatically if fsnotify can't be initialised, or
+// if adding the binary's directory or a [Dir] directory to it fails with
+// ENOSPC (inotify watch limit exhausted, common in containers) or ENOSYS
+// (inotify unavailable), so most callers won't need this; it's mainly useful
+// to force polling in tests or when you know upfront that fsnotify won't
+// work. There is no such fallback for [DirRecursive] trees: if adding one of
+// their directories fails, Do returns the error.
+func WithPoller(interval time.Duration) Option {
+	return func(cfg *config) {
+		cfg.poller = true
+		cfg.pollInterval = interval
+	}
+}
+
+// defaultResyncInterval is used for [WithResync] if no interval is given.
+const defaultResyncInterval = 1 * time.Minute
+
+// WithResync runs onSync on a fixed interval, but only when a hash of the
+// binary, any additional directories (see [Dir]), and any [DirRecursive]
+// trees (respecting their [Include]/[Exclude] filters) actually changed
+// since the last tick; if interval is 0, [defaultResyncInterval] (1 minute)
+// is used.
+//
+// This is a safety net for missed fsnotify events: on flaky or network
+// filesystems events can be dropped silently, and this guarantees a floor on
+// reload latency regardless of whether fsnotify noticed anything. onSync is
+// called from its own goroutine, separately from the callbacks passed to
+// [Dir] or [Exec], so callers can tell a timer-driven reload apart from an
+// event-driven one.
+func WithResync(interval time.Duration, onSync func()) Option {
+	return func(cfg *config) {
+		cfg.resync = true
+		cfg.resyncEvery = interval
+		cfg.onSync = onSync
+	}
+}
+
+// defaultDebounce is the delay used if neither [WithDebounce] nor
+// [WithQuietPeriod] is given.
+const defaultDebounce = 100 * time.Millisecond
+
+// WithDebounce sets the delay between a matching fsnotify event and running
+// the callback; the delay is restarted on every matching event, so a burst of
+// writes only triggers the callback once. Without this option
+// [defaultDebounce] (100ms) is used.
+func WithDebounce(d time.Duration) Option {
+	return func(cfg *config) { cfg.debounce = d }
+}
+
+// WithQuietPeriod requires d to pass with no matching fsnotify events before
+// running the callback; the delay used is the longer of d and the
+// [WithDebounce] delay (not their sum). This is
+// useful to coalesce the burst of writes a build produces into a single
+// reload: set it to comfortably longer than the build takes.
+//
+// When the callback finally runs, [LastEvent] returns the distinct file
+// names and fsnotify operations that accumulated during the window, so [Dir]
+// callbacks can do targeted work instead of reloading everything.
+func WithQuietPeriod(d time.Duration) Option {
+	return func(cfg *config) { cfg.quietPeriod = d }
+}
+
+// Event summarizes the file names and fsnotify operations that accumulated
+// during a single debounce or quiet-period window.
+type Event struct {
+	Names []string
+	Ops   []fsnotify.Op
+}
+
+// LastEvent returns the [Event] that triggered the callback currently
+// running. Call it from inside a [Dir] callback, or from [Exec] via
+// [OnExec], to see what changed; [Dir] and binary-change callbacks always run
+// one at a time (never concurrently with each other), so it's safe to rely
+// on LastEvent reflecting the callback you're in. [DirRecursive] callbacks
+// get their changed path passed directly instead.
+func LastEvent() Event {
+	lastEventMu.Lock()
+	defer lastEventMu.Unlock()
+	return lastEvent
+}
+
+var (
+	lastEventMu sync.Mutex
+	lastEvent   Event
+)
+
+// resetDelay is the duration timers are reset to on a matching event: at
+// least cfg.debounce (or [defaultDebounce]), and at least cfg.quietPeriod if
+// that's longer.
+func resetDelay(cfg config) time.Duration {
+	d := cfg.debounce
+	if d <= 0 {
+		d = defaultDebounce
+	}
+	if cfg.quietPeriod > d {
+		d = cfg.quietPeriod
+	}
+	return d
+}
+
+// eventAccumulator tracks the distinct names and ops seen for each watched
+// path during the current debounce window, so [LastEvent] can report them
+// once the callback actually runs.
+type eventAccumulator struct {
+	mu     sync.Mutex
+	events map[string]*Event
+}
+
+func newEventAccumulator() *eventAccumulator {
+	return &eventAccumulator{events: make(map[string]*Event)}
+}
+
+func (a *eventAccumulator) record(key string, e fsnotify.Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ev := a.events[key]
+	if ev == nil {
+		ev = &Event{}
+		a.events[key] = ev
+	}
+	if !slices.Contains(ev.Names, e.Name) {
+		ev.Names = append(ev.Names, e.Name)
+	}
+	if !slices.Contains(ev.Ops, e.Op) {
+		ev.Ops = append(ev.Ops, e.Op)
+	}
+
+	notify(Notification{Kind: EventDebounced, Time: time.Now(), Path: e.Name, Op: e.Op})
+}
+
+// take returns whatever was recorded for key since the last call, and clears
+// it.
+func (a *eventAccumulator) take(key string) Event {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ev := a.events[key]
+	delete(a.events, key)
+	if ev == nil {
+		return Event{}
+	}
+	return *ev
+}
 
 // Do reload the current process when its binary changes.
 //
@@ -70,12 +305,34 @@ func Dir(path string, cb func()) dir { return dir{path, cb} }
 //
 // The error return will only return initialisation errors. Once initialized it
 // will use the log function to print errors, rather than return.
-func Do(log func(string, ...any), additional ...dir) error {
-	watcher, err := fsnotify.NewWatcher()
+func Do(log func(string, ...any), opts ...Option) error {
+	var cfg config
+	cfg.pollInterval = defaultPollInterval
+	for _, o := range opts {
+		o(&cfg)
+	}
+	if cfg.pollInterval <= 0 {
+		cfg.pollInterval = defaultPollInterval
+	}
+
+	w, usingPoller, err := newWatcher(cfg, log)
 	if err != nil {
 		return fmt.Errorf("reload.Do: cannot setup watcher: %w", err)
 	}
-	closeWatcher = watcher.Close
+	closeWatcher = w.Close
+
+	delay := resetDelay(cfg)
+	events := newEventAccumulator()
+	graceful, gracefulTimeout = cfg.graceful, cfg.gracefulTimeout
+
+	// fire carries the key (binSelf, or an additional directory's path) of a
+	// timer that just expired back to the single loop goroutine below, which
+	// runs the matching callback. These callbacks are never run directly from
+	// the timer's own goroutine: that would let two callbacks race on
+	// [LastEvent], since each timer fires in its own goroutine. [DirRecursive]
+	// callbacks don't go through here; they run from their timer's goroutine.
+	fire := make(chan string)
+	callbacks := make(map[string]func())
 
 	timers := make(map[string]*time.Timer)
 
@@ -83,14 +340,15 @@ func Do(log func(string, ...any), additional ...dir) error {
 	if err != nil {
 		return err
 	}
-	timers[binSelf] = stoppedTimer(Exec)
+	callbacks[binSelf] = Exec
+	timers[binSelf] = stoppedTimer(func() { fire <- binSelf })
 
 	// Watch the directory, because a recompile renames the existing
 	// file (rather than rewriting it), so we won't get events for that.
-	dirs := make([]string, len(additional)+1)
+	dirs := make([]string, len(cfg.additional)+1)
 	dirs[0] = filepath.Dir(binSelf)
 
-	for i, a := range additional {
+	for i, a := range cfg.additional {
 		path, err := filepath.Abs(a.path)
 		if err != nil {
 			return fmt.Errorf("reload.Do: cannot get absolute path to %q: %w",
@@ -106,62 +364,136 @@ func Do(log func(string, ...any), additional ...dir) error {
 				a.path)
 		}
 
-		additional[i].path = path
+		cfg.additional[i].path = path
 		dirs[i+1] = path
-		timers[path] = stoppedTimer(a.cb)
+		callbacks[path] = a.cb
+		timers[path] = stoppedTimer(func() { fire <- path })
+	}
+
+	for i, d := range dirs {
+		if err := w.Add(d); err != nil {
+			if !usingPoller && isWatchLimitErr(err) {
+				log("reload: cannot add %q to watcher: %v; falling back to polling", d, err)
+				w.Close()
+				w = newPollWatcher(cfg.pollInterval)
+				usingPoller = true
+				closeWatcher = w.Close
+				// dirs[:i] were already added to the fsnotify watcher we
+				// just closed, so the poller needs all of them too, not
+				// just d (the one that tripped the limit) — otherwise
+				// whichever directories got in before the limit was hit
+				// (binSelf's own directory included) stop being watched at
+				// all.
+				for _, prev := range dirs[:i+1] {
+					if err := w.Add(prev); err != nil {
+						return fmt.Errorf("reload.Do: cannot add %q to poller: %w", prev, err)
+					}
+				}
+				continue
+			}
+			return fmt.Errorf("reload.Do: cannot add %q to watcher: %w", d, err)
+		}
+	}
+
+	watched := make(map[string]*recursiveDir)
+	if err := setupRecursive(w, &cfg, watched); err != nil {
+		return fmt.Errorf("reload.Do: %w", err)
+	}
+
+	if cfg.resync {
+		go runResync(cfg, log)
 	}
 
 	done := make(chan bool)
 	go func() {
 		for {
 			select {
-			case err, ok := <-watcher.Errors:
+			case err, ok := <-w.Errors():
 				if !ok {
 					return
 				}
 				log("reload error: %v", err)
-			case event, ok := <-watcher.Events:
+				notify(Notification{Kind: EventFSError, Time: time.Now()})
+			case key := <-fire:
+				lastEventMu.Lock()
+				lastEvent = events.take(key)
+				lastEventMu.Unlock()
+
+				kind := EventAdditionalDirChanged
+				if key == binSelf {
+					kind = EventReloadTriggered
+					reloadsTotal.Add(1)
+					lastReloadAt.Store(time.Now().UnixNano())
+				}
+				notify(Notification{Kind: kind, Time: time.Now(), Path: key})
+				callbacks[key]()
+			case event, ok := <-w.Events():
 				if !ok {
 					return
 				}
 
+				if handleRecursiveEvent(w, event, watched, timers, delay, log) {
+					continue
+				}
+
 				trigger := event.Has(fsnotify.Write) || event.Has(fsnotify.Create)
 				if !trigger {
 					continue
 				}
 
 				if event.Name == binSelf {
-					timers[binSelf].Reset(100 * time.Millisecond)
+					events.record(binSelf, event)
+					timers[binSelf].Reset(delay)
 				}
 
-				for _, a := range additional {
+				for _, a := range cfg.additional {
 					if strings.HasPrefix(event.Name, a.path) {
-						timers[a.path].Reset(100 * time.Millisecond)
+						events.record(a.path, event)
+						timers[a.path].Reset(delay)
 					}
 				}
 			}
 		}
 	}()
 
-	for _, d := range dirs {
-		if err := watcher.Add(d); err != nil {
-			return fmt.Errorf("reload.Do: cannot add %q to watcher: %w", d, err)
-		}
-	}
-
 	add := ""
-	if len(additional) > 0 {
+	if len(cfg.additional) > 0 {
 		reldirs := make([]string, len(dirs)-1)
 		for i := range dirs[1:] {
 			reldirs[i] = relpath(dirs[i+1])
 		}
 		add = fmt.Sprintf(" (additional dirs: %s)", strings.Join(reldirs, ", "))
 	}
+	if usingPoller {
+		add += fmt.Sprintf(" (polling every %s)", cfg.pollInterval)
+	}
 	log("restarting %q when it changes%s", relpath(binSelf), add)
 	<-done
 	return nil
 }
 
+// isWatchLimitErr reports whether err looks like fsnotify ran out of watches
+// or isn't usable at all, in which case falling back to polling makes sense.
+func isWatchLimitErr(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.ENOSYS)
+}
+
+// newWatcher sets up the fsnotify-backed watcher, unless cfg.poller is set or
+// fsnotify can't be initialised, in which case it falls back to a polling
+// watcher. The bool return reports whether the poller is in use.
+func newWatcher(cfg config, log func(string, ...any)) (watcher, bool, error) {
+	if cfg.poller {
+		return newPollWatcher(cfg.pollInterval), true, nil
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		log("reload: cannot setup fsnotify watcher: %v; falling back to polling", err)
+		return newPollWatcher(cfg.pollInterval), true, nil
+	}
+	return fsnotifyWatcher{fsw}, false, nil
+}
+
 // OnExec is called before the current process is replaced.
 var OnExec func()
 
@@ -184,8 +516,15 @@ func Exec() {
 		OnExec()
 	}
 
+	if graceful {
+		execGraceful(execName)
+		return
+	}
+
 	err := syscall.Exec(execName, append([]string{execName}, os.Args[1:]...), os.Environ())
 	if err != nil {
+		reloadFailuresTotal.Add(1)
+		notify(Notification{Kind: EventExecFailed, Time: time.Now(), Path: execName})
 		panic(fmt.Sprintf("cannot restart: %v", err))
 	}
 }
@@ -0,0 +1,117 @@
+package reload
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestResetDelay(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config
+		want time.Duration
+	}{
+		{"default", config{}, defaultDebounce},
+		{"debounce only", config{debounce: 50 * time.Millisecond}, 50 * time.Millisecond},
+		{"quiet period longer than debounce", config{debounce: 50 * time.Millisecond, quietPeriod: 200 * time.Millisecond}, 200 * time.Millisecond},
+		{"quiet period shorter than default debounce", config{quietPeriod: 10 * time.Millisecond}, defaultDebounce},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resetDelay(tt.cfg); got != tt.want {
+				t.Errorf("resetDelay(%+v) = %v, want %v", tt.cfg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventAccumulator(t *testing.T) {
+	a := newEventAccumulator()
+
+	if ev := a.take("a"); len(ev.Names) != 0 || len(ev.Ops) != 0 {
+		t.Fatalf("take() on empty accumulator = %+v, want zero value", ev)
+	}
+
+	a.record("a", fsnotify.Event{Name: "a/one.txt", Op: fsnotify.Write})
+	a.record("a", fsnotify.Event{Name: "a/one.txt", Op: fsnotify.Write}) // duplicate, shouldn't appear twice
+	a.record("a", fsnotify.Event{Name: "a/two.txt", Op: fsnotify.Create})
+	a.record("b", fsnotify.Event{Name: "b/three.txt", Op: fsnotify.Write})
+
+	got := a.take("a")
+	if want := []string{"a/one.txt", "a/two.txt"}; !slices.Equal(got.Names, want) {
+		t.Errorf("Names = %v, want %v", got.Names, want)
+	}
+	if want := []fsnotify.Op{fsnotify.Write, fsnotify.Create}; !slices.Equal(got.Ops, want) {
+		t.Errorf("Ops = %v, want %v", got.Ops, want)
+	}
+
+	if ev := a.take("a"); len(ev.Names) != 0 {
+		t.Errorf("second take() for %q = %+v, want zero value (take should clear)", "a", ev)
+	}
+
+	got = a.take("b")
+	if want := []string{"b/three.txt"}; !slices.Equal(got.Names, want) {
+		t.Errorf("Names for %q = %v, want %v", "b", got.Names, want)
+	}
+}
+
+// TestLastEventNoCrossTalk reproduces a bug report against an earlier version
+// of Do: two Dir callbacks whose debounce timers fire close together each saw
+// whichever directory's Event happened to be set last, rather than their own.
+// Do now serializes every Dir/binary callback through a single goroutine (see
+// the "fire" channel in Do) so this can't happen.
+func TestLastEventNoCrossTalk(t *testing.T) {
+	dirA, dirB := t.TempDir(), t.TempDir()
+	fileA, fileB := filepath.Join(dirA, "a.txt"), filepath.Join(dirB, "b.txt")
+	if err := os.WriteFile(fileA, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fileB, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	seenA, seenB := make(chan Event, 1), make(chan Event, 1)
+	go Do(func(string, ...any) {}, WithDebounce(10*time.Millisecond),
+		Dir(dirA, func() { seenA <- LastEvent() }),
+		Dir(dirB, func() { seenB <- LastEvent() }))
+
+	time.Sleep(100 * time.Millisecond) // let the watches get established
+
+	if err := os.WriteFile(fileA, []byte("2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond) // fire the two timers close together, not simultaneously
+	if err := os.WriteFile(fileB, []byte("2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	evA := waitLastEvent(t, seenA, "dirA")
+	evB := waitLastEvent(t, seenB, "dirB")
+
+	for _, n := range evA.Names {
+		if filepath.Dir(n) != dirA {
+			t.Errorf("dirA's callback saw LastEvent for %q, want something under %q", n, dirA)
+		}
+	}
+	for _, n := range evB.Names {
+		if filepath.Dir(n) != dirB {
+			t.Errorf("dirB's callback saw LastEvent for %q, want something under %q", n, dirB)
+		}
+	}
+}
+
+func waitLastEvent(t *testing.T, ch chan Event, label string) Event {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %s's callback to run", label)
+		return Event{}
+	}
+}
@@ -0,0 +1,101 @@
+package reload
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// runResync ticks on cfg.resyncEvery, hashing the binary and any additional
+// directories, and calls cfg.onSync whenever that hash differs from the
+// previous tick. It never returns; it's meant to be started with `go`.
+func runResync(cfg config, log func(string, ...any)) {
+	interval := cfg.resyncEvery
+	if interval <= 0 {
+		interval = defaultResyncInterval
+	}
+
+	last, err := hashWatched(binSelf, cfg.additional, cfg.recursive)
+	if err != nil {
+		log("reload: resync: %v", err)
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for range t.C {
+		h, err := hashWatched(binSelf, cfg.additional, cfg.recursive)
+		if err != nil {
+			log("reload: resync: %v", err)
+			continue
+		}
+		if h != last {
+			last = h
+			cfg.onSync()
+		}
+	}
+}
+
+// hashWatched returns a hash of bin's contents, followed by the contents of
+// every regular file directly inside each additional directory (in the order
+// returned by [Dir] and [os.ReadDir], which is already sorted by name), and
+// every regular file included by each [DirRecursive] tree's [Include] and
+// [Exclude] filters (in [filepath.WalkDir] order, also sorted by name within
+// a directory) — the same filters [handleRecursiveEvent] applies, so a
+// change that wouldn't trigger the fsnotify-driven callback doesn't trigger
+// onSync either.
+func hashWatched(bin string, additional []dir, recursive []recursiveDir) (string, error) {
+	h := sha256.New()
+	if err := hashFile(h, bin); err != nil {
+		return "", err
+	}
+
+	for _, a := range additional {
+		entries, err := os.ReadDir(a.path)
+		if err != nil {
+			return "", err
+		}
+		for _, e := range entries {
+			if !e.Type().IsRegular() {
+				continue
+			}
+			if err := hashFile(h, filepath.Join(a.path, e.Name())); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	for _, rd := range recursive {
+		err := filepath.WalkDir(rd.root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != rd.root && rd.excluded(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || !rd.included(path) {
+				return nil
+			}
+			return hashFile(h, path)
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(h io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(h, f)
+	return err
+}
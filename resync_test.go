@@ -0,0 +1,92 @@
+package reload
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashWatchedAdditionalDirs(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "bin")
+	if err := os.WriteFile(bin, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dirPath := t.TempDir()
+	file := filepath.Join(dirPath, "a.txt")
+	if err := os.WriteFile(file, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	additional := []dir{{path: dirPath}}
+	h1, err := hashWatched(bin, additional, nil)
+	if err != nil {
+		t.Fatalf("hashWatched: %v", err)
+	}
+
+	h2, err := hashWatched(bin, additional, nil)
+	if err != nil {
+		t.Fatalf("hashWatched: %v", err)
+	}
+	if h1 != h2 {
+		t.Errorf("hash changed with no underlying change: %q != %q", h1, h2)
+	}
+
+	if err := os.WriteFile(file, []byte("2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h3, err := hashWatched(bin, additional, nil)
+	if err != nil {
+		t.Fatalf("hashWatched: %v", err)
+	}
+	if h3 == h1 {
+		t.Errorf("hash didn't change after file content changed")
+	}
+}
+
+func TestHashWatchedRecursiveRespectsInclude(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "bin")
+	if err := os.WriteFile(bin, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	tmpl := filepath.Join(root, "a.tmpl")
+	ignored := filepath.Join(root, "a.log")
+	if err := os.WriteFile(tmpl, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ignored, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rd := recursiveDir{root: root, includes: []string{"*.tmpl"}}
+	h1, err := hashWatched(bin, nil, []recursiveDir{rd})
+	if err != nil {
+		t.Fatalf("hashWatched: %v", err)
+	}
+
+	// Changing the file outside the Include filter must not change the hash.
+	if err := os.WriteFile(ignored, []byte("2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h2, err := hashWatched(bin, nil, []recursiveDir{rd})
+	if err != nil {
+		t.Fatalf("hashWatched: %v", err)
+	}
+	if h1 != h2 {
+		t.Errorf("hash changed after editing a file outside the Include filter")
+	}
+
+	// Changing the included file must change the hash.
+	if err := os.WriteFile(tmpl, []byte("2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h3, err := hashWatched(bin, nil, []recursiveDir{rd})
+	if err != nil {
+		t.Fatalf("hashWatched: %v", err)
+	}
+	if h3 == h1 {
+		t.Errorf("hash didn't change after editing a file matching the Include filter")
+	}
+}
@@ -0,0 +1,113 @@
+package reload
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// EventKind identifies the kind of event behind a [Notification].
+type EventKind int
+
+const (
+	// EventFSError is sent when the underlying watcher reports an error.
+	EventFSError EventKind = iota
+	// EventDebounced is sent whenever a matching filesystem event resets a
+	// debounce timer.
+	EventDebounced
+	// EventReloadTriggered is sent right before the process restarts itself.
+	EventReloadTriggered
+	// EventExecFailed is sent when restarting the process failed.
+	EventExecFailed
+	// EventAdditionalDirChanged is sent right before an additional
+	// directory's callback (see [Dir] and [DirRecursive]) runs.
+	EventAdditionalDirChanged
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventFSError:
+		return "FSError"
+	case EventDebounced:
+		return "Debounced"
+	case EventReloadTriggered:
+		return "ReloadTriggered"
+	case EventExecFailed:
+		return "ExecFailed"
+	case EventAdditionalDirChanged:
+		return "AdditionalDirChanged"
+	default:
+		return "Unknown"
+	}
+}
+
+// Notification is a single structured event sent on a [Subscribe] channel.
+type Notification struct {
+	Kind EventKind
+	Time time.Time
+	Path string
+	Op   fsnotify.Op
+}
+
+var (
+	subsMu sync.Mutex
+	subs   []chan Notification
+
+	reloadsTotal        atomic.Int64
+	reloadFailuresTotal atomic.Int64
+	eventsDroppedTotal  atomic.Int64
+	lastReloadAt        atomic.Int64 // UnixNano; 0 means no reload happened yet.
+)
+
+// Stats is a snapshot of reload's running counters, as returned by
+// [GetStats]; github.com/arp242/reload/prometheus exposes the same counters
+// as a prometheus.Collector.
+type Stats struct {
+	ReloadsTotal        int64
+	ReloadFailuresTotal int64
+	EventsDroppedTotal  int64
+	LastReloadAt        time.Time
+}
+
+// GetStats returns a snapshot of reload's counters.
+func GetStats() Stats {
+	var lastReload time.Time
+	if n := lastReloadAt.Load(); n != 0 {
+		lastReload = time.Unix(0, n)
+	}
+	return Stats{
+		ReloadsTotal:        reloadsTotal.Load(),
+		ReloadFailuresTotal: reloadFailuresTotal.Load(),
+		EventsDroppedTotal:  eventsDroppedTotal.Load(),
+		LastReloadAt:        lastReload,
+	}
+}
+
+// Subscribe returns a channel that receives a [Notification] for every
+// structured reload event: fsnotify errors, debounced events, triggered
+// reloads, failed execs, and additional directory changes.
+//
+// The channel is buffered; if a subscriber falls behind, notifications for
+// it are dropped (and counted in [Stats.EventsDroppedTotal]) rather than
+// blocking reload's event loop.
+func Subscribe() <-chan Notification {
+	ch := make(chan Notification, 16)
+	subsMu.Lock()
+	subs = append(subs, ch)
+	subsMu.Unlock()
+	return ch
+}
+
+func notify(n Notification) {
+	subsMu.Lock()
+	defer subsMu.Unlock()
+	for _, ch := range subs {
+		select {
+		case ch <- n:
+		default:
+			eventsDroppedTotal.Add(1)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package reload
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscribeReceivesNotifications(t *testing.T) {
+	ch := Subscribe()
+
+	notify(Notification{Kind: EventFSError, Time: time.Now(), Path: "x"})
+
+	select {
+	case n := <-ch:
+		if n.Kind != EventFSError || n.Path != "x" {
+			t.Errorf("got %+v, want Kind=EventFSError Path=x", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("did not receive notification sent after Subscribe")
+	}
+}
+
+func TestNotifyDropsWhenSubscriberIsFull(t *testing.T) {
+	ch := Subscribe()
+	before := GetStats().EventsDroppedTotal
+
+	// The channel is buffered (see Subscribe); nobody is draining it here,
+	// so sending past its capacity must count as dropped rather than block.
+	for i := 0; i < cap(ch)+5; i++ {
+		notify(Notification{Kind: EventFSError, Time: time.Now()})
+	}
+
+	if after := GetStats().EventsDroppedTotal; after <= before {
+		t.Errorf("EventsDroppedTotal = %d, want more than %d", after, before)
+	}
+}
+
+func TestGetStatsReflectsCounters(t *testing.T) {
+	before := GetStats()
+	reloadsTotal.Add(1)
+	after := GetStats()
+	if after.ReloadsTotal != before.ReloadsTotal+1 {
+		t.Errorf("ReloadsTotal = %d, want %d", after.ReloadsTotal, before.ReloadsTotal+1)
+	}
+}
+
+func TestEventKindString(t *testing.T) {
+	if got := EventReloadTriggered.String(); got != "ReloadTriggered" {
+		t.Errorf("String() = %q, want %q", got, "ReloadTriggered")
+	}
+	if got := EventKind(99).String(); got != "Unknown" {
+		t.Errorf("String() for an unknown kind = %q, want %q", got, "Unknown")
+	}
+}